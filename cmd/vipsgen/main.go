@@ -2,7 +2,10 @@ package main
 
 import (
 	"flag"
+	"io/fs"
 	"log"
+	"os"
+	"path/filepath"
 
 	"github.com/cshum/vipsgen/internal/generator"
 	"github.com/cshum/vipsgen/internal/introspection"
@@ -16,9 +19,20 @@ func main() {
 	templateDirFlag := flag.String("templates", "", "Template directory (uses embedded templates if not specified)")
 	isDebug := flag.Bool("debug", false, "Enable debug json output")
 	includeTest := flag.Bool("include-test", false, "Include test files in generated output")
+	manifestFlag := flag.String("manifest", "", "Manifest file (YAML/JSON) mapping templates to output files; overrides the default one-to-one template mapping")
+	watch := flag.Bool("watch", false, "Watch the template directory (requires --templates) and regenerate on every change")
+	includeSchema := flag.Bool("schema", false, "Emit vips-operations.json describing every discovered operation alongside the generated code")
+	langFlag := flag.String("lang", "go", "Output language backend to use (see Backend.Name in internal/generator)")
+	incremental := flag.Bool("incremental", false, "Skip regenerating output files whose inputs haven't changed since the last run")
+	checkStale := flag.Bool("check-stale", false, "Exit non-zero if the checked-in output was generated from a different libvips version/operation set, without regenerating anything")
 
 	flag.Parse()
 
+	backend, err := generator.GetBackend(*langFlag)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
 	// Extract templates and exit if requested
 	if *extractTemplates {
 		if err := generator.ExtractEmbeddedFS(templates.Templates, *extractDir); err != nil {
@@ -36,15 +50,23 @@ func main() {
 	// Determine template source - use embedded by default, external if specified
 	if *templateDirFlag != "" {
 		// Use specified template directory
-		var err error
-		loader, err = generator.NewOSTemplateLoader(*templateDirFlag, funcMap)
+		osLoader, err := generator.NewOSTemplateLoader(*templateDirFlag, funcMap)
 		if err != nil {
 			log.Fatalf("Failed to create template loader: %v", err)
 		}
+		// Wrap with a raw source reader so HTML templates (*.html.tmpl) can
+		// dispatch through html/template instead of text/template.
+		templateDir := *templateDirFlag
+		loader = generator.WithSourceReader(osLoader, func(name string) ([]byte, error) {
+			return os.ReadFile(filepath.Join(templateDir, name))
+		})
 		log.Printf("Using templates from: %s\n", *templateDirFlag)
 	} else {
 		// Use embedded templates by default
-		loader = generator.NewFSTemplateLoader(templates.Templates, funcMap)
+		fsLoader := generator.NewFSTemplateLoader(templates.Templates, funcMap)
+		loader = generator.WithSourceReader(fsLoader, func(name string) ([]byte, error) {
+			return fs.ReadFile(templates.Templates, name)
+		})
 		log.Printf("Using embedded templates\n")
 	}
 
@@ -75,10 +97,54 @@ func main() {
 	log.Printf("Discovered %d enum types\n", len(enumTypes))
 
 	// Create unified template data
-	templateData := generator.NewTemplateData(vipsVersion, operations, enumTypes, imageTypes, *includeTest)
+	templateData := generator.NewTemplateData(vipsVersion, operations, enumTypes, imageTypes, *includeTest, *includeSchema)
+
+	// Check whether the committed output is stale relative to the
+	// installed libvips, without regenerating anything
+	if *checkStale {
+		stale, err := generator.CheckStale(outputDir, templateData)
+		if err != nil {
+			log.Fatalf("Failed to check staleness: %v", err)
+		}
+		if stale {
+			log.Fatalf("Generated output in %s is stale relative to the installed libvips (version/operation set changed)", outputDir)
+		}
+		log.Printf("Generated output in %s is up to date\n", outputDir)
+		return
+	}
+
+	// Watch the template directory and regenerate on every change
+	if *watch {
+		if *templateDirFlag == "" {
+			log.Fatalf("--watch requires --templates")
+		}
+		if err := generator.Watch(*templateDirFlag, funcMap, templateData, outputDir, backend); err != nil {
+			log.Fatalf("Watch failed: %v", err)
+		}
+		return
+	}
+
+	// Generate all code using the unified template data approach, or a
+	// user-supplied manifest if one was provided
+	if *manifestFlag != "" {
+		manifest, err := generator.LoadManifest(*manifestFlag)
+		if err != nil {
+			log.Fatalf("Failed to load manifest: %v", err)
+		}
+		if err := generator.GenerateFromManifest(loader, templateData, outputDir, manifest, backend); err != nil {
+			log.Fatalf("Failed to generate code from manifest: %v", err)
+		}
+		return
+	}
+
+	if *incremental {
+		if err := generator.GenerateIncremental(loader, templateData, outputDir, backend); err != nil {
+			log.Fatalf("Failed to generate code: %v", err)
+		}
+		return
+	}
 
-	// Generate all code using the unified template data approach
-	if err := generator.Generate(loader, templateData, outputDir); err != nil {
+	if err := generator.Generate(loader, templateData, outputDir, backend); err != nil {
 		log.Fatalf("Failed to generate code: %v", err)
 	}
 }
@@ -0,0 +1,75 @@
+package generator
+
+import (
+	"fmt"
+	"go/format"
+	"sort"
+)
+
+// Backend makes the generated output language a first-class, pluggable
+// concept. Go is the only backend vipsgen ships with, but the interface is
+// deliberately small so community backends (Python ctypes, Node N-API,
+// Rust FFI) can reuse the same libvips introspection pipeline and plug in
+// via RegisterBackend.
+type Backend interface {
+	// Name identifies the backend for the --lang flag, e.g. "go".
+	Name() string
+	// FileExtension is the extension (including the leading dot, e.g.
+	// ".go") of the backend's generated source files. It selects which
+	// output files FormatSource runs on and which templates count as test
+	// templates ("*_test<ext>.tmpl"); output names themselves come from the
+	// template name or the manifest entry.
+	FileExtension() string
+	// FormatSource runs the backend's canonical formatter over generated
+	// source, returning it unchanged if the backend has none.
+	FormatSource(src []byte) ([]byte, error)
+	// TemplateSubdir is the directory under the template root that holds
+	// this backend's templates, e.g. "go" for "templates/go/*.tmpl".
+	TemplateSubdir() string
+}
+
+var backends = map[string]Backend{}
+
+func init() {
+	RegisterBackend(goBackend{})
+}
+
+// RegisterBackend makes a Backend available by name to --lang. Backends
+// typically call this from an init func in their own package.
+func RegisterBackend(backend Backend) {
+	backends[backend.Name()] = backend
+}
+
+// GetBackend looks up a registered backend by name.
+func GetBackend(name string) (Backend, error) {
+	backend, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown backend %q (available: %v)", name, BackendNames())
+	}
+	return backend, nil
+}
+
+// BackendNames returns the names of all registered backends, sorted.
+func BackendNames() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// goBackend is the default, built-in Backend, producing gofmt'd Go source.
+type goBackend struct{}
+
+func (goBackend) Name() string           { return "go" }
+func (goBackend) FileExtension() string  { return ".go" }
+func (goBackend) TemplateSubdir() string { return "go" }
+
+func (goBackend) FormatSource(src []byte) ([]byte, error) {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to gofmt generated source: %v", err)
+	}
+	return formatted, nil
+}
@@ -0,0 +1,237 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// cacheFileName is the name of the content-addressed cache vipsgen
+// maintains inside an output directory between runs of GenerateIncremental.
+const cacheFileName = ".vipsgen-cache.json"
+
+// Cache is the on-disk record of what GenerateIncremental last wrote,
+// keyed by output file, plus a top-level hash of the libvips version and
+// operation set used to produce it.
+type Cache struct {
+	// IntrospectionHash summarizes the libvips version and the full contents
+	// of every discovered operation, enum type and image type (see the
+	// IntrospectionHash function). CI can compare this against a fresh
+	// IntrospectionHash to detect that the installed libvips has drifted
+	// from what was used to generate the committed bindings.
+	IntrospectionHash string `json:"introspectionHash"`
+	// Files maps each generated output file (relative to the output dir)
+	// to the hash of the inputs that produced it.
+	Files map[string]string `json:"files"`
+}
+
+// LoadCache reads the cache file from outputDir, returning an empty Cache
+// if none exists yet.
+func LoadCache(outputDir string) (*Cache, error) {
+	data, err := os.ReadFile(filepath.Join(outputDir, cacheFileName))
+	if os.IsNotExist(err) {
+		return &Cache{Files: map[string]string{}}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read cache: %v", err)
+	}
+
+	var cache Cache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("failed to parse cache: %v", err)
+	}
+	if cache.Files == nil {
+		cache.Files = map[string]string{}
+	}
+	return &cache, nil
+}
+
+// Save writes the cache to outputDir.
+func (c *Cache) Save(outputDir string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, cacheFileName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache: %v", err)
+	}
+	return nil
+}
+
+// IntrospectionHash hashes the libvips version plus the full contents of
+// every operation, enum type and image type in data — arguments, types,
+// flags, defaults and returns included, not just names — so it changes
+// whenever the installed libvips would produce materially different
+// bindings for an *existing* operation (a changed argument default, a
+// retyped flag), not only when operations are added or removed. The hash
+// is insensitive to slice ordering.
+func IntrospectionHash(data *TemplateData) string {
+	subtrees := make([]string, 0, len(data.Operations)+len(data.EnumTypes)+len(data.ImageTypes))
+	for _, op := range data.Operations {
+		subtrees = append(subtrees, "op:"+hashSubtree(op))
+	}
+	for _, enum := range data.EnumTypes {
+		subtrees = append(subtrees, "enum:"+hashSubtree(enum))
+	}
+	for _, imageType := range data.ImageTypes {
+		subtrees = append(subtrees, "image:"+hashSubtree(imageType))
+	}
+	sort.Strings(subtrees)
+
+	h := sha256.New()
+	h.Write([]byte(data.VipsVersion))
+	for _, subtree := range subtrees {
+		h.Write([]byte{0})
+		h.Write([]byte(subtree))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashSubtree returns the hex sha256 of v's JSON encoding, used to fold an
+// entire Operation/EnumTypeInfo/ImageTypeInfo value - not just its name -
+// into IntrospectionHash and fileHash.
+func hashSubtree(v interface{}) string {
+	// Marshaling can't fail for the introspection structs vipsgen feeds it
+	// (no channels/funcs/cyclic values), so a marshal error here would be a
+	// programmer error, not a runtime condition to recover from.
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("generator: failed to hash subtree: %v", err))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// SourceReadingTemplateLoader is implemented by template loaders that can
+// hand back a template's raw source bytes, letting GenerateIncremental fold
+// template edits into its cache key. Loaders that don't implement it still
+// get cache invalidation from IntrospectionHash changes, just not from
+// template-only edits.
+type SourceReadingTemplateLoader interface {
+	ReadTemplateSource(templateFile string) ([]byte, error)
+}
+
+// fileHash computes the cache key for templateFile: the introspection hash
+// (so a libvips upgrade invalidates everything), the backend and template
+// name, and the template's own source bytes when the loader can supply
+// them.
+func fileHash(templateLoader TemplateLoader, templateFile string, introspectionHash string, backend Backend) string {
+	h := sha256.New()
+	h.Write([]byte(introspectionHash))
+	h.Write([]byte{0})
+	h.Write([]byte(backend.Name()))
+	h.Write([]byte{0})
+	h.Write([]byte(templateFile))
+
+	if reader, ok := templateLoader.(SourceReadingTemplateLoader); ok {
+		if src, err := reader.ReadTemplateSource(templateFile); err == nil {
+			h.Write([]byte{0})
+			h.Write(src)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// GenerateIncremental behaves like Generate, but skips writing any output
+// file whose cache key (see fileHash) matches the last run recorded in
+// outputDir/.vipsgen-cache.json and whose file still exists on disk. This
+// turns a big libvips upgrade's regeneration into a diff-sized one.
+func GenerateIncremental(
+	templateLoader TemplateLoader,
+	templateData *TemplateData,
+	outputDir string,
+	backend Backend,
+) error {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	cache, err := LoadCache(outputDir)
+	if err != nil {
+		return err
+	}
+
+	templateFiles, err := templateLoader.ListFiles()
+	if err != nil {
+		return fmt.Errorf("failed to list template files: %v", err)
+	}
+
+	introspectionHash := IntrospectionHash(templateData)
+	cache.IntrospectionHash = introspectionHash
+
+	var generated, skipped int
+	freshFiles := map[string]string{}
+
+	for _, templateFile := range templateFiles {
+		outputFile, ok := resolveOutputFile(templateFile, outputDir, templateData, backend)
+		if !ok {
+			continue
+		}
+
+		rel, err := filepath.Rel(outputDir, outputFile)
+		if err != nil {
+			rel = outputFile
+		}
+
+		hash := fileHash(templateLoader, templateFile, introspectionHash, backend)
+
+		if cache.Files[rel] == hash {
+			if _, err := os.Stat(outputFile); err == nil {
+				freshFiles[rel] = hash
+				skipped++
+				continue
+			}
+		}
+
+		handled, err := RenderHTMLIfApplicable(templateLoader, templateFile, outputFile, templateData)
+		if err != nil {
+			return fmt.Errorf("failed to generate %s: %v", outputFile, err)
+		}
+		if !handled {
+			if err := templateLoader.GenerateFile(templateFile, outputFile, templateData); err != nil {
+				return fmt.Errorf("failed to generate %s: %v", outputFile, err)
+			}
+			if err := formatGeneratedFile(outputFile, backend); err != nil {
+				return err
+			}
+		}
+
+		freshFiles[rel] = hash
+		generated++
+	}
+
+	cache.Files = freshFiles
+	if err := cache.Save(outputDir); err != nil {
+		return err
+	}
+
+	log.Printf("\nIncremental generation: %d written, %d unchanged (skipped)\n", generated, skipped)
+
+	if templateData.IncludeSchema {
+		if err := WriteSchema(templateData, outputDir); err != nil {
+			return fmt.Errorf("failed to write operation schema: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// CheckStale reports whether the libvips version/operation set used to
+// produce the cache in outputDir differs from data's current
+// IntrospectionHash — i.e. whether the checked-in generated code no longer
+// matches the installed libvips. A missing cache counts as stale.
+func CheckStale(outputDir string, data *TemplateData) (bool, error) {
+	cache, err := LoadCache(outputDir)
+	if err != nil {
+		return false, err
+	}
+	if cache.IntrospectionHash == "" {
+		return true, nil
+	}
+	return cache.IntrospectionHash != IntrospectionHash(data), nil
+}
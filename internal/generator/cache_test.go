@@ -0,0 +1,62 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/cshum/vipsgen/internal/introspection"
+)
+
+func TestIntrospectionHash(t *testing.T) {
+	base := func() *TemplateData {
+		return &TemplateData{
+			VipsVersion: "8.15.0",
+			Operations: []introspection.Operation{
+				{Name: "resize"},
+				{Name: "crop"},
+			},
+			EnumTypes: []introspection.EnumTypeInfo{
+				{Name: "VipsKernel"},
+			},
+			ImageTypes: []introspection.ImageTypeInfo{
+				{Name: "jpeg"},
+			},
+		}
+	}
+
+	t.Run("stable across repeated calls", func(t *testing.T) {
+		data := base()
+		if IntrospectionHash(data) != IntrospectionHash(data) {
+			t.Errorf("IntrospectionHash() is not stable across calls on the same data")
+		}
+	})
+
+	t.Run("insensitive to slice ordering", func(t *testing.T) {
+		a := base()
+		b := base()
+		b.Operations[0], b.Operations[1] = b.Operations[1], b.Operations[0]
+
+		if IntrospectionHash(a) != IntrospectionHash(b) {
+			t.Errorf("IntrospectionHash() changed when Operations was reordered")
+		}
+	})
+
+	t.Run("changes when an operation's contents change", func(t *testing.T) {
+		a := base()
+		b := base()
+		b.Operations[0].Name = "thumbnail"
+
+		if IntrospectionHash(a) == IntrospectionHash(b) {
+			t.Errorf("IntrospectionHash() did not change when an operation's contents changed")
+		}
+	})
+
+	t.Run("changes when the libvips version changes", func(t *testing.T) {
+		a := base()
+		b := base()
+		b.VipsVersion = "8.16.0"
+
+		if IntrospectionHash(a) == IntrospectionHash(b) {
+			t.Errorf("IntrospectionHash() did not change when VipsVersion changed")
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
+
+// TemplateEngine is the common surface text/template and html/template
+// already share, letting RenderTemplateSource render either kind of
+// template without caring which package parsed it.
+type TemplateEngine interface {
+	ExecuteTemplate(wr io.Writer, name string, data interface{}) error
+}
+
+// htmlFrontMatter is an optional first line a template can use to opt into
+// HTML-safe rendering without renaming the file, e.g. for a template
+// embedded alongside Go/C sources under the same directory.
+const htmlFrontMatter = "{{/* template-kind: html */}}"
+
+// IsHTMLTemplate reports whether templateFile should be parsed with
+// html/template rather than text/template: either its name follows the
+// "*.html.tmpl" convention, or its source declares the htmlFrontMatter
+// directive on its own first line.
+func IsHTMLTemplate(templateFile string, source []byte) bool {
+	if strings.HasSuffix(templateFile, ".html.tmpl") {
+		return true
+	}
+	firstLine, _, _ := bytes.Cut(source, []byte("\n"))
+	return strings.TrimSpace(string(firstLine)) == htmlFrontMatter
+}
+
+// ParseTemplateEngine parses source as either an html/template or a
+// text/template, chosen by IsHTMLTemplate, and returns it behind the
+// TemplateEngine interface both share.
+func ParseTemplateEngine(name string, source []byte, funcMap texttemplate.FuncMap) (TemplateEngine, error) {
+	return ParseTemplateEngineWithDelims(name, source, funcMap, "", "")
+}
+
+// ParseTemplateEngineWithDelims is ParseTemplateEngine with an explicit
+// delimiter pair. An empty leftDelim/rightDelim falls back to each
+// engine's default ("{{"/"}}"), matching ParseTemplateEngine.
+func ParseTemplateEngineWithDelims(name string, source []byte, funcMap texttemplate.FuncMap, leftDelim, rightDelim string) (TemplateEngine, error) {
+	if IsHTMLTemplate(name, source) {
+		tmpl, err := htmltemplate.New(name).Delims(leftDelim, rightDelim).Funcs(htmltemplate.FuncMap(funcMap)).Parse(string(source))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse HTML template %s: %v", name, err)
+		}
+		return tmpl, nil
+	}
+
+	tmpl, err := texttemplate.New(name).Delims(leftDelim, rightDelim).Funcs(funcMap).Parse(string(source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %v", name, err)
+	}
+	return tmpl, nil
+}
+
+// RenderTemplateSource parses and executes a single template file's source
+// against data, dispatching to html/template or text/template per
+// IsHTMLTemplate. This is what lets a documentation template like
+// operation.html.tmpl render escaped GIR blurbs in the same pass that
+// renders Go/C source from the neighboring *.go.tmpl/*.c.tmpl files.
+func RenderTemplateSource(name string, source []byte, funcMap texttemplate.FuncMap, data interface{}) ([]byte, error) {
+	return RenderTemplateSourceWithDelims(name, source, funcMap, "", "", data)
+}
+
+// RenderTemplateSourceWithDelims is RenderTemplateSource with an explicit
+// delimiter pair, used by manifest entries that declare leftDelim/rightDelim.
+func RenderTemplateSourceWithDelims(name string, source []byte, funcMap texttemplate.FuncMap, leftDelim, rightDelim string, data interface{}) ([]byte, error) {
+	engine, err := ParseTemplateEngineWithDelims(name, source, funcMap, leftDelim, rightDelim)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := engine.ExecuteTemplate(&buf, name, data); err != nil {
+		return nil, fmt.Errorf("failed to execute template %s: %v", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderHTMLIfApplicable renders templateFile through html/template and
+// writes outputFile itself when templateFile is an HTML template (per
+// IsHTMLTemplate) and templateLoader can supply the template's raw source
+// (per SourceReadingTemplateLoader). It reports handled=true whenever it
+// took responsibility for outputFile, so the caller skips the loader's own
+// GenerateFile — which, for any loader that can't supply source, is the
+// only remaining path and therefore can't apply HTML escaping.
+func RenderHTMLIfApplicable(templateLoader TemplateLoader, templateFile, outputFile string, data *TemplateData) (handled bool, err error) {
+	reader, ok := templateLoader.(SourceReadingTemplateLoader)
+	if !ok {
+		return false, nil
+	}
+
+	source, err := reader.ReadTemplateSource(templateFile)
+	if err != nil {
+		return false, nil
+	}
+
+	if !IsHTMLTemplate(templateFile, source) {
+		return false, nil
+	}
+
+	rendered, err := RenderTemplateSource(templateFile, source, GetTemplateFuncMap(), data)
+	if err != nil {
+		return true, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+		return true, fmt.Errorf("failed to create directory for %s: %v", outputFile, err)
+	}
+	if err := os.WriteFile(outputFile, rendered, 0644); err != nil {
+		return true, fmt.Errorf("failed to write %s: %v", outputFile, err)
+	}
+
+	return true, nil
+}
+
+// WithSourceReader wraps loader so it also implements
+// SourceReadingTemplateLoader, fetching a template's raw bytes via read.
+// This lets any TemplateLoader — including the embedded-FS and OS-backed
+// loaders used outside --watch — opt into HTML/text dispatch
+// (RenderHTMLIfApplicable) and into GenerateIncremental's template-aware
+// cache key, without having to implement ReadTemplateSource itself.
+func WithSourceReader(loader TemplateLoader, read func(name string) ([]byte, error)) TemplateLoader {
+	return &sourceReadingLoader{TemplateLoader: loader, read: read}
+}
+
+type sourceReadingLoader struct {
+	TemplateLoader
+	read func(name string) ([]byte, error)
+}
+
+func (s *sourceReadingLoader) ReadTemplateSource(name string) ([]byte, error) {
+	return s.read(name)
+}
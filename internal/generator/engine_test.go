@@ -0,0 +1,51 @@
+package generator
+
+import "testing"
+
+func TestIsHTMLTemplate(t *testing.T) {
+	tests := []struct {
+		name         string
+		templateFile string
+		source       string
+		want         bool
+	}{
+		{
+			name:         "html.tmpl suffix",
+			templateFile: "operation.html.tmpl",
+			source:       "<p>{{.Name}}</p>",
+			want:         true,
+		},
+		{
+			name:         "front matter directive on its own first line",
+			templateFile: "operation.tmpl",
+			source:       "{{/* template-kind: html */}}\n<p>{{.Name}}</p>",
+			want:         true,
+		},
+		{
+			name:         "front matter directive must be the first line",
+			templateFile: "operation.tmpl",
+			source:       "<p>{{.Name}}</p>\n{{/* template-kind: html */}}",
+			want:         false,
+		},
+		{
+			name:         "plain go template",
+			templateFile: "vips.go.tmpl",
+			source:       "package vips\n",
+			want:         false,
+		},
+		{
+			name:         "front matter directive with surrounding whitespace",
+			templateFile: "operation.tmpl",
+			source:       "  {{/* template-kind: html */}}  \n<p>{{.Name}}</p>",
+			want:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsHTMLTemplate(tt.templateFile, []byte(tt.source)); got != tt.want {
+				t.Errorf("IsHTMLTemplate(%q, %q) = %v, want %v", tt.templateFile, tt.source, got, tt.want)
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ func Generate(
 	templateLoader TemplateLoader,
 	templateData *TemplateData,
 	outputDir string,
+	backend Backend,
 ) error {
 	// Ensure output directory exists
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
@@ -29,20 +30,28 @@ func Generate(
 	var generatedFiles []string
 
 	for _, templateFile := range templateFiles {
-		// Convert template name to output filename
-		// For example: "vips.go.tmpl" -> "vips.go"
-		outputFile := filepath.Join(outputDir, strings.TrimSuffix(filepath.Base(templateFile), ".tmpl"))
-
-		// Skip test templates if IncludeTest is false
-		if !templateData.IncludeTest && strings.HasSuffix(filepath.Base(templateFile), "_test.go.tmpl") {
-			log.Printf("Skipping test template: %s (use --include-test to generate)\n", filepath.Base(templateFile))
+		outputFile, ok := resolveOutputFile(templateFile, outputDir, templateData, backend)
+		if !ok {
 			continue
 		}
 
-		// Generate file from template
-		if err := templateLoader.GenerateFile(templateFile, outputFile, templateData); err != nil {
+		// Dispatch HTML templates through html/template's auto-escaping
+		// when the loader can supply raw source; everything else (and any
+		// loader that can't) falls back to its own engine.
+		handled, err := RenderHTMLIfApplicable(templateLoader, templateFile, outputFile, templateData)
+		if err != nil {
 			return fmt.Errorf("failed to generate %s: %v", outputFile, err)
 		}
+
+		if !handled {
+			if err := templateLoader.GenerateFile(templateFile, outputFile, templateData); err != nil {
+				return fmt.Errorf("failed to generate %s: %v", outputFile, err)
+			}
+			if err := formatGeneratedFile(outputFile, backend); err != nil {
+				return err
+			}
+		}
+
 		generatedFiles = append(generatedFiles, outputFile)
 	}
 
@@ -50,5 +59,76 @@ func Generate(
 	for _, file := range generatedFiles {
 		log.Printf("  - %s\n", file)
 	}
+
+	if templateData.IncludeSchema {
+		if err := WriteSchema(templateData, outputDir); err != nil {
+			return fmt.Errorf("failed to write operation schema: %v", err)
+		}
+		log.Printf("  - %s\n", filepath.Join(outputDir, "vips-operations.json"))
+	}
+
+	return nil
+}
+
+// resolveOutputFile maps a template file to its output path, scoping to the
+// selected backend's own templates and skipping test templates when
+// templateData.IncludeTest is false. ok is false when templateFile should
+// not be rendered at all.
+//
+// Templates are expected to live under "<backend.TemplateSubdir()>/*.tmpl"
+// (e.g. "go/vips.go.tmpl"), but the template tree may still be flat (no
+// per-backend subdirectory) — in which case every flat template is treated
+// as belonging to the default "go" backend, matching vipsgen's original
+// one-backend behavior.
+func resolveOutputFile(templateFile, outputDir string, templateData *TemplateData, backend Backend) (outputFile string, ok bool) {
+	slashed := filepath.ToSlash(templateFile)
+	subdirPrefix := backend.TemplateSubdir() + "/"
+
+	switch {
+	case strings.HasPrefix(slashed, subdirPrefix):
+		// Organized as "<lang>/*.tmpl" for this backend.
+	case strings.Contains(slashed, "/"):
+		// Organized as "<lang>/*.tmpl" for a different backend.
+		return "", false
+	case backend.Name() != "go":
+		// Flat (unorganized) template trees only serve the default backend.
+		return "", false
+	}
+
+	// Convert template name to output filename
+	// For example: "go/vips.go.tmpl" -> "vips.go"
+	outputFile = filepath.Join(outputDir, strings.TrimSuffix(filepath.Base(templateFile), ".tmpl"))
+
+	// Skip test templates if IncludeTest is false
+	if !templateData.IncludeTest && strings.HasSuffix(filepath.Base(templateFile), "_test"+backend.FileExtension()+".tmpl") {
+		log.Printf("Skipping test template: %s (use --include-test to generate)\n", filepath.Base(templateFile))
+		return "", false
+	}
+
+	return outputFile, true
+}
+
+// formatGeneratedFile runs backend.FormatSource over outputFile in place,
+// skipping files whose extension doesn't match the backend (e.g. a Go
+// backend leaving a template-emitted README untouched).
+func formatGeneratedFile(outputFile string, backend Backend) error {
+	if filepath.Ext(outputFile) != backend.FileExtension() {
+		return nil
+	}
+
+	src, err := os.ReadFile(outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s for formatting: %v", outputFile, err)
+	}
+
+	formatted, err := backend.FormatSource(src)
+	if err != nil {
+		return fmt.Errorf("failed to format %s: %v", outputFile, err)
+	}
+
+	if err := os.WriteFile(outputFile, formatted, 0644); err != nil {
+		return fmt.Errorf("failed to write formatted %s: %v", outputFile, err)
+	}
+
 	return nil
 }
@@ -0,0 +1,270 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConflictMode controls what happens when a manifest entry's output path
+// already exists on disk.
+type ConflictMode string
+
+const (
+	// ConflictSkip leaves the existing file untouched.
+	ConflictSkip ConflictMode = "skip"
+	// ConflictOverwrite replaces the existing file (the default).
+	ConflictOverwrite ConflictMode = "overwrite"
+	// ConflictAppend appends the rendered output to the existing file.
+	ConflictAppend ConflictMode = "append"
+)
+
+// ManifestFilter narrows the TemplateData passed to a manifest entry's
+// template, so that several entries can render the same template, each over
+// its own subset of operations or enum types. Each entry renders once.
+type ManifestFilter struct {
+	// Operations, if non-empty, restricts TemplateData.Operations to the
+	// named operations (matched against Operation.Name).
+	Operations []string `json:"operations,omitempty" yaml:"operations,omitempty"`
+	// EnumTypes, if non-empty, restricts TemplateData.EnumTypes to the
+	// named enum types (matched against EnumTypeInfo.Name).
+	EnumTypes []string `json:"enumTypes,omitempty" yaml:"enumTypes,omitempty"`
+}
+
+// ManifestEntry describes a single template invocation: which template to
+// render, where to write it, which delimiters to parse it with, how to
+// resolve conflicts with an existing output file, and an optional filter
+// over the operations/enum types visible to the template.
+type ManifestEntry struct {
+	Template   string          `json:"template" yaml:"template"`
+	Output     string          `json:"output" yaml:"output"`
+	LeftDelim  string          `json:"leftDelim,omitempty" yaml:"leftDelim,omitempty"`
+	RightDelim string          `json:"rightDelim,omitempty" yaml:"rightDelim,omitempty"`
+	Conflict   ConflictMode    `json:"conflict,omitempty" yaml:"conflict,omitempty"`
+	Filter     *ManifestFilter `json:"filter,omitempty" yaml:"filter,omitempty"`
+}
+
+// Manifest is a user-supplied description of how templates map to output
+// files, allowing a single template to be rendered multiple times (e.g.
+// once per libvips operation category) instead of the implicit
+// one-to-one "foo.go.tmpl -> foo.go" mapping used by Generate.
+type Manifest struct {
+	Templates []ManifestEntry `json:"templates" yaml:"templates"`
+}
+
+// LoadManifest reads a manifest file, choosing a JSON or YAML parser based
+// on the file extension (".json" vs ".yaml"/".yml").
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %v", path, err)
+	}
+
+	var manifest Manifest
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest %s as JSON: %v", path, err)
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest %s as YAML: %v", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported manifest extension %q (expected .json, .yaml or .yml)", ext)
+	}
+
+	for i, entry := range manifest.Templates {
+		if entry.Template == "" {
+			return nil, fmt.Errorf("manifest entry %d: template is required", i)
+		}
+		if entry.Output == "" {
+			return nil, fmt.Errorf("manifest entry %d: output is required", i)
+		}
+		if entry.Conflict == "" {
+			manifest.Templates[i].Conflict = ConflictOverwrite
+		}
+	}
+
+	return &manifest, nil
+}
+
+// apply returns a copy of data narrowed to the operations/enum types named
+// by the filter. A nil filter returns data unchanged.
+func (f *ManifestFilter) apply(data *TemplateData) *TemplateData {
+	if f == nil {
+		return data
+	}
+
+	filtered := *data
+
+	if len(f.Operations) > 0 {
+		wanted := make(map[string]bool, len(f.Operations))
+		for _, name := range f.Operations {
+			wanted[name] = true
+		}
+		filtered.Operations = nil
+		for _, op := range data.Operations {
+			if wanted[op.Name] {
+				filtered.Operations = append(filtered.Operations, op)
+			}
+		}
+	}
+
+	if len(f.EnumTypes) > 0 {
+		wanted := make(map[string]bool, len(f.EnumTypes))
+		for _, name := range f.EnumTypes {
+			wanted[name] = true
+		}
+		filtered.EnumTypes = nil
+		for _, enum := range data.EnumTypes {
+			if wanted[enum.Name] {
+				filtered.EnumTypes = append(filtered.EnumTypes, enum)
+			}
+		}
+	}
+
+	return &filtered
+}
+
+// generateFileWithDelims renders templateFile with a custom delimiter pair
+// and writes the result to outputFile. Since TemplateLoader itself has no
+// notion of delimiters, this requires templateLoader to implement
+// SourceReadingTemplateLoader so the raw source can be re-parsed here with
+// RenderTemplateSourceWithDelims instead of through the loader's own engine.
+func generateFileWithDelims(templateLoader TemplateLoader, templateFile, outputFile string, data *TemplateData, leftDelim, rightDelim string) error {
+	reader, ok := templateLoader.(SourceReadingTemplateLoader)
+	if !ok {
+		return fmt.Errorf("template loader does not support custom delimiters, required by manifest entry %q", templateFile)
+	}
+
+	source, err := reader.ReadTemplateSource(templateFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", templateFile, err)
+	}
+
+	rendered, err := RenderTemplateSourceWithDelims(templateFile, source, GetTemplateFuncMap(), leftDelim, rightDelim, data)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(outputFile, rendered, 0644)
+}
+
+// GenerateFromManifest renders each entry in manifest using templateLoader,
+// narrowing templateData per-entry via the entry's filter and resolving
+// output conflicts per the entry's Conflict mode. Unlike Generate, a single
+// template may appear in multiple entries, each producing a distinct output
+// file. Every output file with the backend's FileExtension is run through
+// backend.FormatSource once, after
+// all entries that target it have been rendered (a ConflictAppend output
+// gets the same combined-then-formatted treatment as Generate's single-pass
+// files), and templateData.IncludeSchema is honored the same way Generate
+// honors it.
+func GenerateFromManifest(
+	templateLoader TemplateLoader,
+	templateData *TemplateData,
+	outputDir string,
+	manifest *Manifest,
+	backend Backend,
+) error {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	// ConflictAppend entries accumulate onto outputFile across the whole
+	// run; seenThisRun tracks which output files have already received a
+	// write this run, so the first write to a given path truncates rather
+	// than appending onto whatever a *previous* run of vipsgen left there.
+	seenThisRun := map[string]bool{}
+	var touchedFiles []string
+
+	for _, entry := range manifest.Templates {
+		outputFile := filepath.Join(outputDir, entry.Output)
+
+		if entry.Conflict == ConflictSkip {
+			if _, err := os.Stat(outputFile); err == nil {
+				log.Printf("Skipping %s (already exists)\n", outputFile)
+				continue
+			}
+		}
+
+		data := entry.Filter.apply(templateData)
+
+		if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %v", outputFile, err)
+		}
+
+		renderTo := outputFile
+		if entry.Conflict == ConflictAppend {
+			if !seenThisRun[outputFile] {
+				if err := os.Remove(outputFile); err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("failed to truncate %s: %v", outputFile, err)
+				}
+			}
+			renderTo = outputFile + ".manifest-part"
+		}
+
+		if entry.LeftDelim != "" || entry.RightDelim != "" {
+			if err := generateFileWithDelims(templateLoader, entry.Template, renderTo, data, entry.LeftDelim, entry.RightDelim); err != nil {
+				return fmt.Errorf("failed to generate %s: %v", outputFile, err)
+			}
+		} else if err := templateLoader.GenerateFile(entry.Template, renderTo, data); err != nil {
+			return fmt.Errorf("failed to generate %s: %v", outputFile, err)
+		}
+
+		if entry.Conflict == ConflictAppend {
+			if err := appendFile(renderTo, outputFile); err != nil {
+				return fmt.Errorf("failed to append %s onto %s: %v", renderTo, outputFile, err)
+			}
+		}
+
+		if !seenThisRun[outputFile] {
+			seenThisRun[outputFile] = true
+			touchedFiles = append(touchedFiles, outputFile)
+		}
+
+		log.Printf("  - %s (from %s)\n", outputFile, entry.Template)
+	}
+
+	for _, outputFile := range touchedFiles {
+		if err := formatGeneratedFile(outputFile, backend); err != nil {
+			return err
+		}
+	}
+
+	if templateData.IncludeSchema {
+		if err := WriteSchema(templateData, outputDir); err != nil {
+			return fmt.Errorf("failed to write operation schema: %v", err)
+		}
+		log.Printf("  - %s\n", filepath.Join(outputDir, "vips-operations.json"))
+	}
+
+	return nil
+}
+
+// appendFile appends the contents of src onto dst, creating dst if it
+// doesn't already exist, then removes src.
+func appendFile(src, dst string) error {
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(dst, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(content); err != nil {
+		return err
+	}
+
+	return os.Remove(src)
+}
@@ -0,0 +1,192 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cshum/vipsgen/internal/introspection"
+)
+
+func TestLoadManifest(t *testing.T) {
+	tests := []struct {
+		name         string
+		ext          string
+		content      string
+		wantErr      bool
+		wantConflict ConflictMode
+	}{
+		{
+			name: "json defaults conflict to overwrite",
+			ext:  ".json",
+			content: `{"templates": [
+				{"template": "foo.go.tmpl", "output": "foo.go"}
+			]}`,
+			wantConflict: ConflictOverwrite,
+		},
+		{
+			name: "yaml preserves explicit conflict mode",
+			ext:  ".yaml",
+			content: `
+templates:
+  - template: foo.go.tmpl
+    output: foo.go
+    conflict: skip
+`,
+			wantConflict: ConflictSkip,
+		},
+		{
+			name:    "missing template is an error",
+			ext:     ".json",
+			content: `{"templates": [{"output": "foo.go"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "missing output is an error",
+			ext:     ".json",
+			content: `{"templates": [{"template": "foo.go.tmpl"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported extension is an error",
+			ext:     ".toml",
+			content: `templates = []`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "manifest"+tt.ext)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write manifest: %v", err)
+			}
+
+			manifest, err := LoadManifest(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("LoadManifest() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadManifest() error = %v, want nil", err)
+			}
+			if got := manifest.Templates[0].Conflict; got != tt.wantConflict {
+				t.Errorf("Templates[0].Conflict = %q, want %q", got, tt.wantConflict)
+			}
+		})
+	}
+}
+
+func TestManifestFilter_apply(t *testing.T) {
+	data := &TemplateData{
+		Operations: []introspection.Operation{{Name: "resize"}, {Name: "crop"}, {Name: "rotate"}},
+		EnumTypes:  []introspection.EnumTypeInfo{{Name: "VipsKernel"}, {Name: "VipsAngle"}},
+	}
+
+	t.Run("nil filter returns data unchanged", func(t *testing.T) {
+		var filter *ManifestFilter
+		got := filter.apply(data)
+		if got != data {
+			t.Errorf("apply() = %p, want original data %p", got, data)
+		}
+	})
+
+	t.Run("narrows to named operations", func(t *testing.T) {
+		filter := &ManifestFilter{Operations: []string{"crop"}}
+		got := filter.apply(data)
+		if len(got.Operations) != 1 || got.Operations[0].Name != "crop" {
+			t.Errorf("apply().Operations = %v, want [crop]", got.Operations)
+		}
+		if len(got.EnumTypes) != len(data.EnumTypes) {
+			t.Errorf("apply() unexpectedly narrowed EnumTypes: %v", got.EnumTypes)
+		}
+	})
+
+	t.Run("narrows to named enum types", func(t *testing.T) {
+		filter := &ManifestFilter{EnumTypes: []string{"VipsAngle"}}
+		got := filter.apply(data)
+		if len(got.EnumTypes) != 1 || got.EnumTypes[0].Name != "VipsAngle" {
+			t.Errorf("apply().EnumTypes = %v, want [VipsAngle]", got.EnumTypes)
+		}
+	})
+
+	t.Run("unmatched name yields empty slice, not the original", func(t *testing.T) {
+		filter := &ManifestFilter{Operations: []string{"nonexistent"}}
+		got := filter.apply(data)
+		if len(got.Operations) != 0 {
+			t.Errorf("apply().Operations = %v, want empty", got.Operations)
+		}
+	})
+}
+
+// fakeManifestLoader is a minimal TemplateLoader that writes a fixed string
+// to outputFile instead of rendering an actual template, so
+// GenerateFromManifest's conflict-resolution logic can be tested in
+// isolation from the template engine.
+type fakeManifestLoader struct {
+	content string
+}
+
+func (f *fakeManifestLoader) ListFiles() ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeManifestLoader) GenerateFile(templateFile, outputFile string, data *TemplateData) error {
+	return os.WriteFile(outputFile, []byte(f.content), 0644)
+}
+
+func TestGenerateFromManifest_ConflictSkip(t *testing.T) {
+	outputDir := t.TempDir()
+	existing := filepath.Join(outputDir, "foo.go")
+	if err := os.WriteFile(existing, []byte("// untouched\n"), 0644); err != nil {
+		t.Fatalf("failed to seed existing output: %v", err)
+	}
+
+	manifest := &Manifest{Templates: []ManifestEntry{
+		{Template: "foo.go.tmpl", Output: "foo.go", Conflict: ConflictSkip},
+	}}
+	loader := &fakeManifestLoader{content: "package foo\n"}
+
+	if err := GenerateFromManifest(loader, &TemplateData{}, outputDir, manifest, goBackend{}); err != nil {
+		t.Fatalf("GenerateFromManifest() error = %v", err)
+	}
+
+	got, err := os.ReadFile(existing)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if string(got) != "// untouched\n" {
+		t.Errorf("ConflictSkip overwrote existing output: %q", got)
+	}
+}
+
+func TestGenerateFromManifest_ConflictAppend(t *testing.T) {
+	outputDir := t.TempDir()
+	manifest := &Manifest{Templates: []ManifestEntry{
+		{Template: "a.go.tmpl", Output: "combined.go", Conflict: ConflictAppend},
+		{Template: "b.go.tmpl", Output: "combined.go", Conflict: ConflictAppend},
+	}}
+	loader := &fakeManifestLoader{content: "x"}
+
+	run := func() string {
+		if err := GenerateFromManifest(loader, &TemplateData{}, outputDir, manifest, goBackend{}); err != nil {
+			t.Fatalf("GenerateFromManifest() error = %v", err)
+		}
+		got, err := os.ReadFile(filepath.Join(outputDir, "combined.go"))
+		if err != nil {
+			t.Fatalf("failed to read output: %v", err)
+		}
+		return string(got)
+	}
+
+	if got, want := run(), "xx"; got != want {
+		t.Errorf("after first run = %q, want %q", got, want)
+	}
+	// A second run over the same manifest must not grow the file further -
+	// each run starts the append chain from scratch.
+	if got, want := run(), "xx"; got != want {
+		t.Errorf("after second run = %q, want %q (append grew across runs)", got, want)
+	}
+}
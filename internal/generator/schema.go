@@ -0,0 +1,101 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cshum/vipsgen/internal/introspection"
+)
+
+// SchemaVersion identifies the shape of the document written by
+// WriteSchema. Bump it whenever a field is added, removed or renamed so
+// downstream consumers (language bindings, doc generators, IDE plugins)
+// can detect incompatible changes without re-running libvips GIR
+// discovery themselves.
+const SchemaVersion = "1"
+
+// SchemaDocument is the machine-readable description of every operation,
+// enum type and image type vipsgen discovered via introspection, written
+// to vips-operations.json when TemplateData.IncludeSchema is set.
+type SchemaDocument struct {
+	SchemaVersion  string                        `json:"schemaVersion"`
+	LibvipsVersion string                        `json:"libvipsVersion"`
+	Operations     []introspection.Operation     `json:"operations"`
+	EnumTypes      []introspection.EnumTypeInfo  `json:"enumTypes"`
+	ImageTypes     []introspection.ImageTypeInfo `json:"imageTypes"`
+}
+
+// NewSchemaDocument builds a SchemaDocument from data.
+func NewSchemaDocument(data *TemplateData) *SchemaDocument {
+	return &SchemaDocument{
+		SchemaVersion:  SchemaVersion,
+		LibvipsVersion: data.VipsVersion,
+		Operations:     data.Operations,
+		EnumTypes:      data.EnumTypes,
+		ImageTypes:     data.ImageTypes,
+	}
+}
+
+// WriteSchema writes vips-operations.json (and its companion JSON Schema,
+// vips-operations.schema.json) describing data to outputDir. Unlike the
+// rest of Generate, this is produced directly in Go rather than via a
+// template, since it's a data document rather than source code.
+func WriteSchema(data *TemplateData, outputDir string) error {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	doc := NewSchemaDocument(data)
+	docJSON, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema document: %v", err)
+	}
+
+	docPath := filepath.Join(outputDir, "vips-operations.json")
+	if err := os.WriteFile(docPath, docJSON, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", docPath, err)
+	}
+
+	metaPath := filepath.Join(outputDir, "vips-operations.schema.json")
+	if err := os.WriteFile(metaPath, []byte(operationsJSONSchema), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", metaPath, err)
+	}
+
+	return nil
+}
+
+// operationsJSONSchema is a JSON Schema (draft-07) describing the document
+// written by WriteSchema, so consumers can validate vips-operations.json
+// before relying on it.
+const operationsJSONSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "https://github.com/cshum/vipsgen/vips-operations.schema.json",
+  "title": "vipsgen operation schema",
+  "type": "object",
+  "required": ["schemaVersion", "libvipsVersion", "operations", "enumTypes", "imageTypes"],
+  "properties": {
+    "schemaVersion": {
+      "type": "string",
+      "description": "Version of this document's shape, bumped on breaking changes."
+    },
+    "libvipsVersion": {
+      "type": "string",
+      "description": "Version of libvips the introspection data was generated from."
+    },
+    "operations": {
+      "type": "array",
+      "description": "Every libvips operation discovered via GObject Introspection."
+    },
+    "enumTypes": {
+      "type": "array",
+      "description": "Every enum type referenced by an operation argument or return value."
+    },
+    "imageTypes": {
+      "type": "array",
+      "description": "Image formats supported by the introspected libvips build."
+    }
+  }
+}
+`
@@ -0,0 +1,50 @@
+package generator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cshum/vipsgen/internal/introspection"
+)
+
+func TestWriteSchema(t *testing.T) {
+	outputDir := t.TempDir()
+	data := &TemplateData{
+		VipsVersion: "8.15.0",
+		Operations:  []introspection.Operation{{Name: "resize"}},
+		EnumTypes:   []introspection.EnumTypeInfo{{Name: "VipsKernel"}},
+		ImageTypes:  []introspection.ImageTypeInfo{{Name: "jpeg"}},
+	}
+
+	if err := WriteSchema(data, outputDir); err != nil {
+		t.Fatalf("WriteSchema() error = %v", err)
+	}
+
+	docPath := filepath.Join(outputDir, "vips-operations.json")
+	docBytes, err := os.ReadFile(docPath)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", docPath, err)
+	}
+
+	var doc SchemaDocument
+	if err := json.Unmarshal(docBytes, &doc); err != nil {
+		t.Fatalf("failed to parse %s: %v", docPath, err)
+	}
+
+	if doc.SchemaVersion != SchemaVersion {
+		t.Errorf("SchemaVersion = %q, want %q", doc.SchemaVersion, SchemaVersion)
+	}
+	if doc.LibvipsVersion != data.VipsVersion {
+		t.Errorf("LibvipsVersion = %q, want %q", doc.LibvipsVersion, data.VipsVersion)
+	}
+	if len(doc.Operations) != 1 || doc.Operations[0].Name != "resize" {
+		t.Errorf("Operations = %v, want [resize]", doc.Operations)
+	}
+
+	metaPath := filepath.Join(outputDir, "vips-operations.schema.json")
+	if _, err := os.Stat(metaPath); err != nil {
+		t.Errorf("expected %s to exist: %v", metaPath, err)
+	}
+}
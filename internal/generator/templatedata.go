@@ -11,6 +11,10 @@ type TemplateData struct {
 	EnumTypes   []introspection.EnumTypeInfo
 	ImageTypes  []introspection.ImageTypeInfo
 	IncludeTest bool
+	// IncludeSchema, when set, causes Generate to additionally emit
+	// vips-operations.json describing Operations/EnumTypes/ImageTypes in a
+	// stable, versioned schema (see WriteSchema).
+	IncludeSchema bool
 }
 
 // NewTemplateData creates a new TemplateData structure with all needed information
@@ -20,12 +24,14 @@ func NewTemplateData(
 	enumTypes []introspection.EnumTypeInfo,
 	imageTypes []introspection.ImageTypeInfo,
 	includeTest bool,
+	includeSchema bool,
 ) *TemplateData {
 	return &TemplateData{
-		VipsVersion: vipsVersion,
-		Operations:  operations,
-		EnumTypes:   enumTypes,
-		ImageTypes:  imageTypes,
-		IncludeTest: includeTest,
+		VipsVersion:   vipsVersion,
+		Operations:    operations,
+		EnumTypes:     enumTypes,
+		ImageTypes:    imageTypes,
+		IncludeTest:   includeTest,
+		IncludeSchema: includeSchema,
 	}
 }
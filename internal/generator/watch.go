@@ -0,0 +1,170 @@
+package generator
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DevConfig controls development-mode behavior for OS-backed template
+// loaders. With NoCache set, the loader returned by NewDevTemplateLoader
+// re-parses every template from disk on each ListFiles/GenerateFile call
+// instead of reusing a cached *template.Template, so edits to a template
+// file are picked up without restarting vipsgen.
+type DevConfig struct {
+	// NoCache disables template caching, forcing a fresh parse from disk
+	// on every render. Intended for --watch and other iterative workflows
+	// where template files change between runs of the same process.
+	NoCache bool
+}
+
+// devTemplateLoader wraps an OS template directory and, when configured
+// with NoCache, constructs a brand new TemplateLoader (and therefore
+// re-reads and re-parses every template) on every call instead of reusing
+// whatever internal cache NewOSTemplateLoader maintains.
+type devTemplateLoader struct {
+	templateDir string
+	funcMap     template.FuncMap
+	config      DevConfig
+	cached      TemplateLoader
+}
+
+// NewDevTemplateLoader returns a TemplateLoader rooted at templateDir whose
+// caching behavior is governed by config. This is what --watch uses to
+// guarantee each regeneration reflects the template files as they are on
+// disk at that moment.
+func NewDevTemplateLoader(templateDir string, funcMap template.FuncMap, config DevConfig) (TemplateLoader, error) {
+	loader := &devTemplateLoader{templateDir: templateDir, funcMap: funcMap, config: config}
+	if !config.NoCache {
+		cached, err := NewOSTemplateLoader(templateDir, funcMap)
+		if err != nil {
+			return nil, err
+		}
+		loader.cached = cached
+	}
+	return loader, nil
+}
+
+func (d *devTemplateLoader) loader() (TemplateLoader, error) {
+	if !d.config.NoCache {
+		return d.cached, nil
+	}
+	return NewOSTemplateLoader(d.templateDir, d.funcMap)
+}
+
+func (d *devTemplateLoader) ListFiles() ([]string, error) {
+	loader, err := d.loader()
+	if err != nil {
+		return nil, err
+	}
+	return loader.ListFiles()
+}
+
+// ReadTemplateSource reads templateFile's raw bytes straight off disk,
+// satisfying SourceReadingTemplateLoader so GenerateIncremental's cache key
+// sees template edits even when NoCache is set.
+func (d *devTemplateLoader) ReadTemplateSource(templateFile string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(d.templateDir, templateFile))
+}
+
+// GenerateFile delegates to the wrapped loader. devTemplateLoader
+// implements SourceReadingTemplateLoader (see ReadTemplateSource above), so
+// callers like Generate dispatch *.html.tmpl templates through
+// html/template themselves via RenderHTMLIfApplicable before ever reaching
+// here.
+func (d *devTemplateLoader) GenerateFile(templateFile, outputFile string, data *TemplateData) error {
+	loader, err := d.loader()
+	if err != nil {
+		return err
+	}
+	return loader.GenerateFile(templateFile, outputFile, data)
+}
+
+// addDirRecursive adds root and every directory beneath it to watcher,
+// since fsnotify watches are not recursive on their own.
+func addDirRecursive(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}
+
+// Watch regenerates output from templateDir on every change to a template
+// file under it, reusing templateData (and therefore the libvips
+// introspection results baked into it) across runs so only the cheap
+// template parse/render step repeats. It returns an error only when setting
+// up the watch fails; otherwise it blocks until the watcher's Events or
+// Errors channel closes, logging regeneration and watch errors as it goes.
+func Watch(templateDir string, funcMap template.FuncMap, templateData *TemplateData, outputDir string, backend Backend) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// fsnotify watches are non-recursive, but templates live under
+	// <templateDir>/<lang>/*.tmpl, so the lang subdirectories need their own
+	// watch too.
+	if err := addDirRecursive(watcher, templateDir); err != nil {
+		return err
+	}
+
+	loader, err := NewDevTemplateLoader(templateDir, funcMap, DevConfig{NoCache: true})
+	if err != nil {
+		return err
+	}
+
+	regenerate := func() {
+		if err := Generate(loader, templateData, outputDir, backend); err != nil {
+			log.Printf("Failed to regenerate: %v\n", err)
+			return
+		}
+		log.Printf("Regenerated output from %s\n", templateDir)
+	}
+
+	log.Printf("Watching %s for changes (Ctrl+C to stop)\n", templateDir)
+	regenerate()
+
+	// Debounce bursts of filesystem events (e.g. an editor's save-as-rename
+	// dance) into a single regeneration.
+	var debounce *time.Timer
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) == 0 {
+				continue
+			}
+			// A newly created lang subdirectory (e.g. "templates/python/")
+			// needs its own watch, or edits inside it go unnoticed.
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := addDirRecursive(watcher, event.Name); err != nil {
+						log.Printf("Failed to watch new directory %s: %v\n", event.Name, err)
+					}
+				}
+			}
+			if debounce != nil {
+				debounce.Stop()
+			}
+			debounce = time.AfterFunc(100*time.Millisecond, regenerate)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("Watch error: %v\n", err)
+		}
+	}
+}